@@ -1,6 +1,11 @@
 package socketio
 
-import "sync"
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
 
 // BroadcastAdaptor is the adaptor to handle broadcast.
 type BroadcastAdaptor interface {
@@ -21,42 +26,348 @@ type BroadcastAdaptor interface {
 
 	// Send will send the message with args to room. If ignore is not nil, it won't send to the socket ignore.
 	Send(ignore Socket, room, message string, args ...interface{}) error
+
+	// LeaveAll removes socket from every room it has joined.
+	LeaveAll(socket Socket) error
+
+	// Clear removes every socket from room.
+	Clear(room string) error
+
+	// SendAll sends message with args to every socket in every room. A socket joined to more than
+	// one room only receives the message once.
+	SendAll(message string, args ...interface{}) error
+
+	// ForEach calls fn once for every socket currently in room.
+	ForEach(room string, fn func(Socket)) error
+
+	// Len returns the number of sockets currently in room.
+	Len(room string) int
+
+	// SendVolatile behaves like Send, but skips any socket whose outbound queue is already full
+	// instead of waiting on it.
+	SendVolatile(ignore Socket, room, message string, args ...interface{}) error
+
+	// SendBinary behaves like Send, but routes []byte and io.Reader args through the engineio
+	// binary packet path instead of JSON-encoding them.
+	SendBinary(ignore Socket, room, message string, args ...interface{}) error
+
+	// SendWithAck behaves like Send, but assigns every recipient an ack id and streams an
+	// AckResult for each as it replies or ctx is done. The returned channel is closed once every
+	// recipient has been accounted for.
+	SendWithAck(ctx context.Context, ignore Socket, room, message string, args ...interface{}) (<-chan AckResult, error)
 }
 
 var newBroadcast = newBroadcastDefault
 
+// SetAdaptor overrides the BroadcastAdaptor used by Servers created afterwards, e.g. to swap
+// in a cluster-aware adaptor such as the one in adapter/redis. It must be called before
+// constructing a Server.
+func SetAdaptor(adaptor BroadcastAdaptor) {
+	newBroadcast = func() BroadcastAdaptor {
+		return adaptor
+	}
+}
+
+// RoomOp identifies the kind of room membership change described by a RoomEvent.
+type RoomOp int
+
+const (
+	// RoomJoined fires when a socket joins a room.
+	RoomJoined RoomOp = iota
+	// RoomLeft fires when a socket leaves a room, including via LeaveAll.
+	RoomLeft
+	// RoomCleared fires when a room is wiped via Clear. Socket is nil.
+	RoomCleared
+)
+
+// RoomEvent describes a single room membership change, as delivered to a RoomEventSink.
+type RoomEvent struct {
+	Op     RoomOp
+	Room   string
+	Socket Socket
+}
+
+// RoomEventSink receives RoomEvents as they happen. A sink whose Update returns an error is
+// unregistered and closed.
+type RoomEventSink interface {
+	Update(RoomEvent) error
+	io.Closer
+}
+
+// DropPolicy controls what Broadcast.Send does with a message for a socket whose outbound
+// queue is already full.
+type DropPolicy int
+
+const (
+	// Block keeps retrying delivery in the background for up to BroadcastOptions.WriteWait,
+	// then gives up and drops the message. The retry never runs on the Send call path, so a
+	// backpressured socket still can't stall the broadcaster.
+	Block DropPolicy = iota
+	// DropOldest discards the oldest queued message to make room for the new one.
+	DropOldest
+	// Disconnect keeps retrying delivery in the background for up to BroadcastOptions.WriteWait,
+	// then evicts the socket (LeaveAll + Disconnect) if it is still full.
+	Disconnect
+)
+
+// BroadcastOptions configures the per-socket outbound queue Broadcast.Send delivers onto.
+type BroadcastOptions struct {
+	// QueueSize is the number of buffered messages each socket's outbound queue holds.
+	// Defaults to 32.
+	QueueSize int
+
+	// WriteWait is how long a message for a full queue keeps being retried in the background
+	// under Block and Disconnect before it is dropped or the socket is evicted. Send itself
+	// never waits, and DropOldest does not use it. Defaults to one second.
+	WriteWait time.Duration
+
+	// DropPolicy controls what happens to a message for a socket whose queue is full.
+	DropPolicy DropPolicy
+}
+
+func normalizeBroadcastOptions(opts BroadcastOptions) BroadcastOptions {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 32
+	}
+	if opts.WriteWait <= 0 {
+		opts.WriteWait = time.Second
+	}
+	return opts
+}
+
+// outboundMessage is one queued Send call, awaiting delivery by a socket's writer goroutine.
+type outboundMessage struct {
+	message string
+	args    []interface{}
+}
+
+// socketOutbox is the bounded outbound queue and writer goroutine for a single joined socket.
+// refs counts the rooms the socket is currently in; the writer goroutine is torn down once refs
+// drops to zero.
+type socketOutbox struct {
+	ch   chan outboundMessage
+	done chan struct{}
+	refs int
+}
+
+// AckResult is one reply collected by SendWithAck, delivered as each recipient acknowledges or
+// once the call's context is done.
+type AckResult struct {
+	Sid  string
+	Data interface{}
+	Err  error
+}
+
 // Broadcast is a set of "room" each with a set of Socket
-type broadcast struct {
+type Broadcast struct {
 	roomSet       map[string]map[string]Socket
+	socketRooms   map[string]map[string]struct{} // socket id -> set of rooms it has joined
 	broadcastLock sync.RWMutex
+
+	sinkLock sync.Mutex
+	sinks    map[RoomEventSink]struct{}
+
+	opts     BroadcastOptions
+	outboxes map[string]*socketOutbox // socket id -> its outbound queue
+}
+
+// NewBroadcast returns the default in-memory BroadcastAdaptor, configured with opts. Unlike
+// SetAdaptor, callers keep the concrete *Broadcast so they can call Register on it to subscribe
+// a RoomEventSink; BroadcastAdaptor itself does not expose Register.
+func NewBroadcast(opts BroadcastOptions) *Broadcast {
+	return newBroadcastWithOptions(opts)
+}
+
+// SetBroadcastOptions changes the outbound-queue options used by the default in-memory
+// BroadcastAdaptor for Servers created afterwards. Call SetAdaptor instead to use a different
+// adaptor altogether.
+func SetBroadcastOptions(opts BroadcastOptions) {
+	newBroadcast = func() BroadcastAdaptor {
+		return newBroadcastWithOptions(opts)
+	}
+}
+
+func newBroadcastWithOptions(opts BroadcastOptions) *Broadcast {
+	return &Broadcast{
+		roomSet:     make(map[string]map[string]Socket),
+		socketRooms: make(map[string]map[string]struct{}),
+		sinks:       make(map[RoomEventSink]struct{}),
+		outboxes:    make(map[string]*socketOutbox),
+		opts:        normalizeBroadcastOptions(opts),
+	}
 }
 
 func newBroadcastDefault() BroadcastAdaptor {
-	return &broadcast{
-		roomSet: make(map[string]map[string]Socket),
+	return newBroadcastWithOptions(BroadcastOptions{})
+}
+
+// acquireOutbox adds one reference to socket's outbound queue, creating it and starting its
+// writer goroutine the first time socket joins a room. Caller must hold broadcastLock.
+func (b *Broadcast) acquireOutbox(socket Socket) {
+	ob, ok := b.outboxes[socket.Id()]
+	if !ok {
+		ob = &socketOutbox{
+			ch:   make(chan outboundMessage, b.opts.QueueSize),
+			done: make(chan struct{}),
+		}
+		b.outboxes[socket.Id()] = ob
+		go b.writeLoop(socket, ob)
+	}
+	ob.refs++
+}
+
+// releaseOutbox drops one reference to sid's outbound queue, tearing down its writer goroutine
+// once the socket has left its last room. Caller must hold broadcastLock.
+func (b *Broadcast) releaseOutbox(sid string) {
+	ob, ok := b.outboxes[sid]
+	if !ok {
+		return
+	}
+	ob.refs--
+	if ob.refs <= 0 {
+		close(ob.done)
+		delete(b.outboxes, sid)
+	}
+}
+
+// writeLoop delivers queued messages to socket one at a time until ob is torn down. Each Emit
+// runs in its own goroutine so a socket whose Emit never returns (a permanently stuck write)
+// can't park writeLoop itself forever: it still observes ob.done and exits on teardown, even
+// though the stuck Emit call leaks for the life of that one goroutine.
+func (b *Broadcast) writeLoop(socket Socket, ob *socketOutbox) {
+	for {
+		select {
+		case msg := <-ob.ch:
+			emitted := make(chan struct{})
+			go func() {
+				socket.Emit(msg.message, msg.args...)
+				close(emitted)
+			}()
+			select {
+			case <-emitted:
+			case <-ob.done:
+				return
+			}
+		case <-ob.done:
+			return
+		}
+	}
+}
+
+// enqueue delivers msg onto ob. It never blocks the caller: a full queue is resolved
+// immediately (DropOldest) or retried off-goroutine for up to opts.WriteWait (Block,
+// Disconnect), so one backpressured recipient can never stall the broadcast that called us.
+func (b *Broadcast) enqueue(socket Socket, ob *socketOutbox, msg outboundMessage) {
+	select {
+	case ob.ch <- msg:
+		return
+	default:
+	}
+
+	switch b.opts.DropPolicy {
+	case DropOldest:
+		select {
+		case <-ob.ch:
+		default:
+		}
+		select {
+		case ob.ch <- msg:
+		default:
+		}
+	case Disconnect:
+		go b.retryThenEvict(socket, ob, msg)
+	default: // Block
+		go b.retryThenDrop(ob, msg)
+	}
+}
+
+// retryThenDrop keeps trying to deliver msg onto ob in the background for up to
+// opts.WriteWait, then gives up. Runs off the Send call path so a full queue never blocks it.
+func (b *Broadcast) retryThenDrop(ob *socketOutbox, msg outboundMessage) {
+	timer := time.NewTimer(b.opts.WriteWait)
+	defer timer.Stop()
+	select {
+	case ob.ch <- msg:
+	case <-timer.C:
+	}
+}
+
+// retryThenEvict keeps trying to deliver msg onto ob in the background for up to
+// opts.WriteWait, then evicts socket if it is still full. Runs off the Send call path so a
+// full queue never blocks it.
+func (b *Broadcast) retryThenEvict(socket Socket, ob *socketOutbox, msg outboundMessage) {
+	timer := time.NewTimer(b.opts.WriteWait)
+	defer timer.Stop()
+	select {
+	case ob.ch <- msg:
+	case <-timer.C:
+		b.evict(socket)
+	}
+}
+
+// evict forcibly removes a backpressured socket from every room and disconnects it.
+func (b *Broadcast) evict(socket Socket) {
+	b.LeaveAll(socket)
+	socket.Disconnect()
+}
+
+// Register adds sink to the set of RoomEventSinks notified of every Join, Leave, LeaveAll and
+// Clear. The returned cancel func unregisters it.
+func (b *Broadcast) Register(sink RoomEventSink) (cancel func()) {
+	b.sinkLock.Lock()
+	b.sinks[sink] = struct{}{}
+	b.sinkLock.Unlock()
+
+	return func() {
+		b.sinkLock.Lock()
+		delete(b.sinks, sink)
+		b.sinkLock.Unlock()
+	}
+}
+
+// fanOut delivers event to every registered sink, dropping and closing any sink whose Update
+// returns an error. The sink set is snapshotted under sinkLock and Update is called outside it,
+// so a sink whose Update blocks (e.g. ChannelRoomEventSink backed by a full channel) stalls the
+// call that is fanning out to it, but holds no lock that would stall Join/Leave/LeaveAll/Clear
+// calls made from other goroutines.
+func (b *Broadcast) fanOut(event RoomEvent) {
+	b.sinkLock.Lock()
+	sinks := make([]RoomEventSink, 0, len(b.sinks))
+	for sink := range b.sinks {
+		sinks = append(sinks, sink)
+	}
+	b.sinkLock.Unlock()
+
+	for _, sink := range sinks {
+		if err := sink.Update(event); err != nil {
+			b.sinkLock.Lock()
+			delete(b.sinks, sink)
+			b.sinkLock.Unlock()
+			sink.Close()
+		}
 	}
 }
 
 // Get the joined rooms
-func (b *broadcast) Rooms(socket Socket) []string {
+func (b *Broadcast) Rooms(socket Socket) []string {
 	b.broadcastLock.RLock()
 	defer b.broadcastLock.RUnlock()
 
 	ret := []string{}
-	for room, sockets := range b.roomSet {
-		if socket == nil {
+	if socket == nil {
+		for room := range b.roomSet {
 			ret = append(ret, room)
-		} else {
-			if _, has := sockets[socket.Id()]; has {
-				ret = append(ret, room)
-			}
 		}
+		return ret
+	}
+	for room := range b.socketRooms[socket.Id()] {
+		ret = append(ret, room)
 	}
 	return ret
 }
 
 // Whether room exists
-func (b *broadcast) Has(room string, socket Socket) bool {
+func (b *Broadcast) Has(room string, socket Socket) bool {
 	b.broadcastLock.RLock()
 	defer b.broadcastLock.RUnlock()
 
@@ -76,7 +387,7 @@ func (b *broadcast) Has(room string, socket Socket) bool {
 }
 
 // Get all the links in the specified room
-func (b *broadcast) Clients(room string) (sockets map[string]Socket) {
+func (b *Broadcast) Clients(room string) (sockets map[string]Socket) {
 	b.broadcastLock.RLock()
 	defer b.broadcastLock.RUnlock()
 
@@ -86,9 +397,8 @@ func (b *broadcast) Clients(room string) (sockets map[string]Socket) {
 }
 
 // Join into a room
-func (b *broadcast) Join(room string, socket Socket) error {
+func (b *Broadcast) Join(room string, socket Socket) error {
 	b.broadcastLock.Lock()
-	defer b.broadcastLock.Unlock()
 
 	sockets, ok := b.roomSet[room]
 	if !ok {
@@ -96,17 +406,46 @@ func (b *broadcast) Join(room string, socket Socket) error {
 	}
 	sockets[socket.Id()] = socket
 	b.roomSet[room] = sockets
+
+	rooms, ok := b.socketRooms[socket.Id()]
+	if !ok {
+		rooms = make(map[string]struct{})
+		b.socketRooms[socket.Id()] = rooms
+	}
+	_, alreadyJoined := rooms[room]
+	if !alreadyJoined {
+		rooms[room] = struct{}{}
+		b.acquireOutbox(socket)
+	}
+	b.broadcastLock.Unlock()
+
+	if !alreadyJoined {
+		b.fanOut(RoomEvent{Op: RoomJoined, Room: room, Socket: socket})
+	}
 	return nil
 }
 
 // Disconnect from a room
-func (b *broadcast) Leave(room string, socket Socket) error {
+func (b *Broadcast) Leave(room string, socket Socket) error {
 	b.broadcastLock.Lock()
-	defer b.broadcastLock.Unlock()
+	left := b.leaveLocked(room, socket)
+	b.broadcastLock.Unlock()
+
+	if left {
+		b.fanOut(RoomEvent{Op: RoomLeft, Room: room, Socket: socket})
+	}
+	return nil
+}
 
+// leaveLocked removes socket from room, reporting whether socket was actually a member of it.
+// Caller must hold broadcastLock.
+func (b *Broadcast) leaveLocked(room string, socket Socket) bool {
 	sockets, ok := b.roomSet[room]
 	if !ok {
-		return nil
+		return false
+	}
+	if _, had := sockets[socket.Id()]; !had {
+		return false
 	}
 	delete(sockets, socket.Id())
 	if len(sockets) == 0 {
@@ -114,27 +453,258 @@ func (b *broadcast) Leave(room string, socket Socket) error {
 	} else {
 		b.roomSet[room] = sockets
 	}
+
+	if rooms, ok := b.socketRooms[socket.Id()]; ok {
+		delete(rooms, room)
+		if len(rooms) == 0 {
+			delete(b.socketRooms, socket.Id())
+		}
+	}
+
+	b.releaseOutbox(socket.Id())
+	return true
+}
+
+// LeaveAll removes socket from every room it has joined.
+func (b *Broadcast) LeaveAll(socket Socket) error {
+	b.broadcastLock.Lock()
+	rooms := make([]string, 0, len(b.socketRooms[socket.Id()]))
+	for room := range b.socketRooms[socket.Id()] {
+		rooms = append(rooms, room)
+	}
+	for _, room := range rooms {
+		b.leaveLocked(room, socket)
+	}
+	b.broadcastLock.Unlock()
+
+	for _, room := range rooms {
+		b.fanOut(RoomEvent{Op: RoomLeft, Room: room, Socket: socket})
+	}
+	return nil
+}
+
+// Clear removes every socket from room.
+func (b *Broadcast) Clear(room string) error {
+	b.broadcastLock.Lock()
+	sockets, ok := b.roomSet[room]
+	if !ok {
+		b.broadcastLock.Unlock()
+		return nil
+	}
+	for id := range sockets {
+		if rooms, ok := b.socketRooms[id]; ok {
+			delete(rooms, room)
+			if len(rooms) == 0 {
+				delete(b.socketRooms, id)
+			}
+		}
+		b.releaseOutbox(id)
+	}
+	delete(b.roomSet, room)
+	b.broadcastLock.Unlock()
+
+	b.fanOut(RoomEvent{Op: RoomCleared, Room: room})
 	return nil
 }
 
 // Perform a brodcast send to all the sockets in a "room" except the ignored socket.
 // Brodcast send to all with ignore == nil.
-func (b *broadcast) Send(ignore Socket, room, message string, args ...interface{}) error {
+func (b *Broadcast) Send(ignore Socket, room, message string, args ...interface{}) error {
+	return b.sendToRoom(ignore, room, message, args)
+}
+
+// sendToRoom snapshots room's membership under broadcastLock, then releases it and delivers to
+// each socket's outbound queue so a single backpressured client can't stall the broadcast or
+// hold the lock.
+func (b *Broadcast) sendToRoom(ignore Socket, room, message string, args []interface{}) error {
 	b.broadcastLock.RLock()
-	defer b.broadcastLock.RUnlock()
+	sockets := b.roomSet[room]
+	targets := make([]Socket, 0, len(sockets))
+	outboxes := make([]*socketOutbox, 0, len(sockets))
+	for id, s := range sockets {
+		if ignore != nil && ignore.Id() == id {
+			continue
+		}
+		if ob, ok := b.outboxes[id]; ok {
+			targets = append(targets, s)
+			outboxes = append(outboxes, ob)
+		}
+	}
+	b.broadcastLock.RUnlock()
 
+	msg := outboundMessage{message: message, args: args}
+	for i, ob := range outboxes {
+		b.enqueue(targets[i], ob, msg)
+	}
+	return nil
+}
+
+// SendVolatile behaves like Send, but skips any socket whose outbound queue is already full
+// instead of waiting on it or applying DropPolicy.
+func (b *Broadcast) SendVolatile(ignore Socket, room, message string, args ...interface{}) error {
+	b.broadcastLock.RLock()
 	sockets := b.roomSet[room]
+	outboxes := make([]*socketOutbox, 0, len(sockets))
+	for id := range sockets {
+		if ignore != nil && ignore.Id() == id {
+			continue
+		}
+		if ob, ok := b.outboxes[id]; ok {
+			outboxes = append(outboxes, ob)
+		}
+	}
+	b.broadcastLock.RUnlock()
+
+	msg := outboundMessage{message: message, args: args}
+	for _, ob := range outboxes {
+		select {
+		case ob.ch <- msg:
+		default:
+			// transport is still buffering the previous message; drop this one.
+		}
+	}
+	return nil
+}
+
+// SendBinary behaves like Send, but converts []byte and io.Reader args into raw []byte payloads
+// instead of leaving them to be JSON-encoded, so the engineio parser ships them as binary
+// attachments.
+func (b *Broadcast) SendBinary(ignore Socket, room, message string, args ...interface{}) error {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if r, ok := arg.(io.Reader); ok {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			converted[i] = data
+			continue
+		}
+		converted[i] = arg
+	}
+	return b.sendToRoom(ignore, room, message, converted)
+}
+
+// SendWithAck sends message with args to every socket in room (skipping ignore). The returned
+// channel receives one AckResult per recipient as it replies or ctx is done, and is closed once
+// every recipient is accounted for.
+func (b *Broadcast) SendWithAck(ctx context.Context, ignore Socket, room, message string, args ...interface{}) (<-chan AckResult, error) {
+	b.broadcastLock.RLock()
+	sockets := b.roomSet[room]
+	targets := make([]Socket, 0, len(sockets))
 	for id, s := range sockets {
 		if ignore != nil && ignore.Id() == id {
 			continue
 		}
-		s.Emit(message, args...)
+		targets = append(targets, s)
+	}
+	b.broadcastLock.RUnlock()
+
+	results := make(chan AckResult, len(targets))
+	if len(targets) == 0 {
+		close(results)
+		return results, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for _, s := range targets {
+		sid := s.Id()
+		done := make(chan struct{})
+		var once sync.Once
+		// resolve sends res and closes done together, both guarded by the same once, so a
+		// duplicate or retried ack call for sid can never close an already-closed done.
+		resolve := func(res AckResult) {
+			once.Do(func() {
+				results <- res
+				close(done)
+			})
+		}
+
+		ackFn := func(data interface{}) {
+			resolve(AckResult{Sid: sid, Data: data})
+		}
+
+		err := s.Emit(message, append(append([]interface{}{}, args...), ackFn)...)
+		if err != nil {
+			resolve(AckResult{Sid: sid, Err: err})
+		}
+
+		go func() {
+			defer wg.Done()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				resolve(AckResult{Sid: sid, Err: ctx.Err()})
+			}
+		}()
+	}
+
+	return results, nil
+}
+
+// SendAll sends message with args to every socket in every room. A socket joined to more than
+// one room only receives the message once. Like Send, delivery goes through each socket's
+// outbound queue so a single backpressured client can't stall the broadcast or hold the lock.
+func (b *Broadcast) SendAll(message string, args ...interface{}) error {
+	b.broadcastLock.RLock()
+	sent := make(map[string]struct{})
+	targets := make([]Socket, 0, len(b.outboxes))
+	outboxes := make([]*socketOutbox, 0, len(b.outboxes))
+	for _, sockets := range b.roomSet {
+		for id, s := range sockets {
+			if _, ok := sent[id]; ok {
+				continue
+			}
+			sent[id] = struct{}{}
+			if ob, ok := b.outboxes[id]; ok {
+				targets = append(targets, s)
+				outboxes = append(outboxes, ob)
+			}
+		}
+	}
+	b.broadcastLock.RUnlock()
+
+	msg := outboundMessage{message: message, args: args}
+	for i, ob := range outboxes {
+		b.enqueue(targets[i], ob, msg)
 	}
 	return nil
 }
 
+// ForEach calls fn once for every socket currently in room. Membership is snapshotted under
+// broadcastLock and released before fn runs, so fn is free to call back into Join/Leave/LeaveAll
+// /Clear/Send* on the same Broadcast without deadlocking.
+func (b *Broadcast) ForEach(room string, fn func(Socket)) error {
+	b.broadcastLock.RLock()
+	sockets := b.roomSet[room]
+	targets := make([]Socket, 0, len(sockets))
+	for _, s := range sockets {
+		targets = append(targets, s)
+	}
+	b.broadcastLock.RUnlock()
+
+	for _, s := range targets {
+		fn(s)
+	}
+	return nil
+}
+
+// Len returns the number of sockets currently in room.
+func (b *Broadcast) Len(room string) int {
+	b.broadcastLock.RLock()
+	defer b.broadcastLock.RUnlock()
+
+	return len(b.roomSet[room])
+}
+
 // return the number of connections in a specified room
-func (b *broadcast) NumberInRoom(room string) (rv int, err error) {
+func (b *Broadcast) NumberInRoom(room string) (rv int, err error) {
 	b.broadcastLock.RLock()
 	defer b.broadcastLock.RUnlock()
 
@@ -147,7 +717,7 @@ func (b *broadcast) NumberInRoom(room string) (rv int, err error) {
 }
 
 // return the number of rooms
-func (b *broadcast) NumberOfRooms(room string) (rv int, err error) {
+func (b *Broadcast) NumberOfRooms(room string) (rv int, err error) {
 	b.broadcastLock.RLock()
 	defer b.broadcastLock.RUnlock()
 
@@ -159,7 +729,7 @@ func (b *broadcast) NumberOfRooms(room string) (rv int, err error) {
 }
 
 // return the names of the rooms as a slice of strings
-func (b *broadcast) ListOfRooms(room string) (rv []string, err error) {
+func (b *Broadcast) ListOfRooms(room string) (rv []string, err error) {
 	b.broadcastLock.RLock()
 	defer b.broadcastLock.RUnlock()
 
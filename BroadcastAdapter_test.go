@@ -0,0 +1,501 @@
+package socketio
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSocket is a minimal Socket used to exercise Broadcast without a real transport. Emit
+// records every call so tests can assert on what was actually delivered. If block is set, every
+// Emit waits on it first, letting a test simulate a permanently backpressured recipient;
+// emitStarted is closed the moment the first Emit call begins, so a test can synchronize with
+// it before block ever gets closed (if it ever does).
+type fakeSocket struct {
+	id          string
+	block       chan struct{}
+	emitStarted chan struct{}
+
+	emitStartedOnce sync.Once
+	mu              sync.Mutex
+	emitted         []string
+	disconnect      int
+}
+
+func newFakeSocket(id string) *fakeSocket {
+	return &fakeSocket{id: id, emitStarted: make(chan struct{})}
+}
+
+func (s *fakeSocket) Id() string { return s.id }
+
+func (s *fakeSocket) Emit(name string, args ...interface{}) error {
+	s.emitStartedOnce.Do(func() { close(s.emitStarted) })
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emitted = append(s.emitted, name)
+	return nil
+}
+
+func (s *fakeSocket) Disconnect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.disconnect++
+	return nil
+}
+
+func (s *fakeSocket) emittedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.emitted)
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !cond() {
+		t.Fatalf("condition not met within %s", timeout)
+	}
+}
+
+func TestBroadcastLeaveAll(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	sock := newFakeSocket("s1")
+
+	b.Join("lobby", sock)
+	b.Join("game", sock)
+
+	if got := b.Len("lobby"); got != 1 {
+		t.Fatalf("Len(lobby) = %d, want 1", got)
+	}
+
+	if err := b.LeaveAll(sock); err != nil {
+		t.Fatalf("LeaveAll: %v", err)
+	}
+
+	if got := b.Len("lobby"); got != 0 {
+		t.Errorf("Len(lobby) after LeaveAll = %d, want 0", got)
+	}
+	if got := b.Len("game"); got != 0 {
+		t.Errorf("Len(game) after LeaveAll = %d, want 0", got)
+	}
+	if rooms := b.Rooms(sock); len(rooms) != 0 {
+		t.Errorf("Rooms(sock) after LeaveAll = %v, want empty", rooms)
+	}
+}
+
+func TestBroadcastClear(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	s1, s2 := newFakeSocket("s1"), newFakeSocket("s2")
+
+	b.Join("lobby", s1)
+	b.Join("lobby", s2)
+
+	if err := b.Clear("lobby"); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+
+	if b.Has("lobby", nil) {
+		t.Error("Has(lobby) is true after Clear")
+	}
+	if got := b.Len("lobby"); got != 0 {
+		t.Errorf("Len(lobby) after Clear = %d, want 0", got)
+	}
+	if rooms := b.Rooms(s1); len(rooms) != 0 {
+		t.Errorf("Rooms(s1) after Clear = %v, want empty", rooms)
+	}
+
+	// Clearing a room that was never created is a no-op, not an error.
+	if err := b.Clear("never-existed"); err != nil {
+		t.Errorf("Clear(never-existed): %v", err)
+	}
+}
+
+func TestBroadcastSendAll(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	s1, s2 := newFakeSocket("s1"), newFakeSocket("s2")
+
+	b.Join("lobby", s1)
+	b.Join("lobby", s2)
+	b.Join("game", s1) // s1 is in two rooms; SendAll must still only reach it once.
+
+	if err := b.SendAll("hello"); err != nil {
+		t.Fatalf("SendAll: %v", err)
+	}
+
+	waitFor(t, time.Second, func() bool {
+		return s1.emittedCount() == 1 && s2.emittedCount() == 1
+	})
+}
+
+func TestBroadcastForEach(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	sockets := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		s := newFakeSocket(fmt.Sprintf("s%d", i))
+		sockets[s.Id()] = false
+		b.Join("lobby", s)
+	}
+
+	err := b.ForEach("lobby", func(s Socket) {
+		sockets[s.Id()] = true
+	})
+	if err != nil {
+		t.Fatalf("ForEach: %v", err)
+	}
+
+	for id, seen := range sockets {
+		if !seen {
+			t.Errorf("ForEach never visited %s", id)
+		}
+	}
+}
+
+func TestBroadcastForEachReentrant(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	for i := 0; i < 3; i++ {
+		b.Join("lobby", newFakeSocket(fmt.Sprintf("s%d", i)))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.ForEach("lobby", func(s Socket) {
+			b.Leave("lobby", s)
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ForEach deadlocked calling back into Leave")
+	}
+
+	if n := b.Len("lobby"); n != 0 {
+		t.Errorf("Len(\"lobby\") = %d, want 0", n)
+	}
+}
+
+func TestBroadcastRegisterFanOut(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	sink := NewChannelRoomEventSink(4)
+	cancel := b.Register(sink)
+	defer cancel()
+
+	sock := newFakeSocket("s1")
+	b.Join("lobby", sock)
+	b.Leave("lobby", sock)
+
+	// Leaving a room the socket was never in must not produce a phantom RoomLeft event.
+	b.Leave("never-joined", sock)
+
+	var got []RoomOp
+	for len(got) < 2 {
+		select {
+		case ev := <-sink.Events:
+			got = append(got, ev.Op)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for events, got %v so far", got)
+		}
+	}
+
+	if got[0] != RoomJoined || got[1] != RoomLeft {
+		t.Fatalf("events = %v, want [RoomJoined RoomLeft]", got)
+	}
+
+	select {
+	case ev := <-sink.Events:
+		t.Fatalf("unexpected extra event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Once cancelled, the sink must no longer be fanned out to.
+	cancel()
+	b.Join("other-room", sock)
+
+	select {
+	case ev := <-sink.Events:
+		t.Fatalf("sink received event %+v after cancel", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBroadcastJoinRefcountIsIdempotent(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	sock := newFakeSocket("s1")
+
+	// Joining the same room twice must not double the outbox refcount: a single Leave should
+	// be enough to tear the writer goroutine down, not leak it.
+	b.Join("lobby", sock)
+	b.Join("lobby", sock)
+	b.Leave("lobby", sock)
+
+	b.broadcastLock.RLock()
+	_, stillTracked := b.outboxes[sock.Id()]
+	b.broadcastLock.RUnlock()
+
+	if stillTracked {
+		t.Error("outbox for socket still tracked after a single Leave following a duplicate Join")
+	}
+}
+
+func TestBroadcastEvictsOnFullQueueUnderDisconnectPolicy(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{
+		QueueSize:  1,
+		WriteWait:  10 * time.Millisecond,
+		DropPolicy: Disconnect,
+	})
+	sock := newFakeSocket("s1")
+	sock.block = make(chan struct{}) // never closed: every Emit call hangs forever.
+	b.Join("lobby", sock)
+
+	// The writer goroutine picks up the first message and blocks in Emit forever, so the
+	// queue (size 1) fills on the second Send and stays full for every one after that, giving
+	// retryThenEvict's WriteWait window a chance to expire.
+	for i := 0; i < 5; i++ {
+		if err := b.Send(nil, "lobby", "msg"); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	waitFor(t, time.Second, func() bool {
+		sock.mu.Lock()
+		defer sock.mu.Unlock()
+		return sock.disconnect > 0
+	})
+}
+
+func TestBroadcastWriteLoopExitsWhenSocketEmitHangs(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	sock := newFakeSocket("s1")
+	sock.block = make(chan struct{}) // never closed: the Emit call below hangs forever.
+	b.Join("lobby", sock)
+
+	baseline := runtime.NumGoroutine()
+
+	if err := b.Send(nil, "lobby", "msg"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case <-sock.emitStarted:
+	case <-time.After(time.Second):
+		t.Fatal("socket.Emit was never called")
+	}
+
+	// writeLoop is now parked waiting on a socket.Emit call that will never return. Leaving
+	// every room must still let the writer goroutine itself exit and stop counting against the
+	// process's goroutines, even though the stuck Emit call it spawned leaks forever (there is
+	// no way to cancel an arbitrary blocking Socket.Emit from here).
+	if err := b.LeaveAll(sock); err != nil {
+		t.Fatalf("LeaveAll: %v", err)
+	}
+
+	waitFor(t, time.Second, func() bool {
+		return runtime.NumGoroutine() <= baseline
+	})
+}
+
+func TestBroadcastSendBinaryPropagatesReadError(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	sock := newFakeSocket("s1")
+	b.Join("lobby", sock)
+
+	wantErr := fmt.Errorf("boom")
+	err := b.SendBinary(nil, "lobby", "msg", errorReader{err: wantErr})
+	if err != wantErr {
+		t.Fatalf("SendBinary error = %v, want %v", err, wantErr)
+	}
+}
+
+// errorReader is an io.Reader whose Read always fails, used to exercise SendBinary's error path.
+type errorReader struct{ err error }
+
+func (r errorReader) Read(p []byte) (int, error) { return 0, r.err }
+
+// duplicateAckSocket invokes the ack callback SendWithAck appends to its args twice, simulating
+// a duplicate or retried ack packet from a client.
+type duplicateAckSocket struct{ id string }
+
+func (s *duplicateAckSocket) Id() string { return s.id }
+
+func (s *duplicateAckSocket) Emit(name string, args ...interface{}) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if ackFn, ok := args[len(args)-1].(func(interface{})); ok {
+		ackFn("first")
+		ackFn("second")
+	}
+	return nil
+}
+
+func (s *duplicateAckSocket) Disconnect() error { return nil }
+
+func TestBroadcastSendWithAckToleratesDuplicateAck(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	sock := &duplicateAckSocket{id: "s1"}
+	b.Join("lobby", sock)
+
+	// A second close(done) for the same recipient must not panic the process.
+	results, err := b.SendWithAck(context.Background(), nil, "lobby", "msg")
+	if err != nil {
+		t.Fatalf("SendWithAck: %v", err)
+	}
+
+	select {
+	case res, ok := <-results:
+		if !ok {
+			t.Fatal("results closed before delivering a result")
+		}
+		if res.Sid != "s1" || res.Data != "first" {
+			t.Fatalf("result = %+v, want Sid=s1 Data=first", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for ack result")
+	}
+
+	select {
+	case _, ok := <-results:
+		if ok {
+			t.Fatal("received a second result for a single recipient")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("results channel never closed")
+	}
+}
+
+func TestBroadcastSendWithAckRespectsContextDeadline(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{})
+	// fakeSocket.Emit never invokes the ack callback it's handed, so the only way this
+	// recipient's AckResult can arrive is via ctx's deadline.
+	sock := newFakeSocket("s1")
+	b.Join("lobby", sock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	results, err := b.SendWithAck(ctx, nil, "lobby", "msg")
+	if err != nil {
+		t.Fatalf("SendWithAck: %v", err)
+	}
+
+	select {
+	case res, ok := <-results:
+		if !ok {
+			t.Fatal("results closed before delivering a result")
+		}
+		if res.Sid != "s1" || res.Err != context.DeadlineExceeded {
+			t.Fatalf("result = %+v, want Sid=s1 Err=DeadlineExceeded", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the context-deadline result")
+	}
+
+	select {
+	case _, ok := <-results:
+		if ok {
+			t.Fatal("received a second result for a single recipient")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("results channel never closed")
+	}
+}
+
+func TestBroadcastSendVolatileDropsOnFullQueue(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{QueueSize: 1})
+	sock := newFakeSocket("s1")
+	sock.block = make(chan struct{}) // never closed: the first Emit call hangs forever.
+	b.Join("lobby", sock)
+
+	if err := b.Send(nil, "lobby", "first"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case <-sock.emitStarted:
+	case <-time.After(time.Second):
+		t.Fatal("writer never picked up the first message")
+	}
+
+	// The writer already drained "first" out of the queue into its stuck Emit call, so the
+	// queue (size 1) is empty again: "second" fits. "third" then finds it full and, unlike
+	// Send, SendVolatile must drop it immediately rather than waiting or applying DropPolicy.
+	if err := b.SendVolatile(nil, "lobby", "second"); err != nil {
+		t.Fatalf("SendVolatile: %v", err)
+	}
+	if err := b.SendVolatile(nil, "lobby", "third"); err != nil {
+		t.Fatalf("SendVolatile: %v", err)
+	}
+
+	b.broadcastLock.RLock()
+	ob := b.outboxes[sock.Id()]
+	b.broadcastLock.RUnlock()
+
+	select {
+	case msg := <-ob.ch:
+		if msg.message != "second" {
+			t.Fatalf("queued message = %q, want %q", msg.message, "second")
+		}
+	default:
+		t.Fatal("expected \"second\" to still be queued")
+	}
+	select {
+	case msg := <-ob.ch:
+		t.Fatalf("unexpected extra queued message %q; \"third\" should have been dropped", msg.message)
+	default:
+	}
+}
+
+func TestBroadcastDropOldestReplacesQueuedMessage(t *testing.T) {
+	b := NewBroadcast(BroadcastOptions{QueueSize: 1, DropPolicy: DropOldest})
+	sock := newFakeSocket("s1")
+	sock.block = make(chan struct{}) // never closed: the first Emit call hangs forever.
+	b.Join("lobby", sock)
+
+	if err := b.Send(nil, "lobby", "first"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case <-sock.emitStarted:
+	case <-time.After(time.Second):
+		t.Fatal("writer never picked up the first message")
+	}
+
+	// The writer already drained "first" out of the queue into its stuck Emit call, so the
+	// queue (size 1) is empty again: "second" fits. "third" then finds it full and, under
+	// DropOldest, must evict "second" to make room for itself instead of being dropped itself.
+	if err := b.Send(nil, "lobby", "second"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := b.Send(nil, "lobby", "third"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	b.broadcastLock.RLock()
+	ob := b.outboxes[sock.Id()]
+	b.broadcastLock.RUnlock()
+
+	select {
+	case msg := <-ob.ch:
+		if msg.message != "third" {
+			t.Fatalf("queued message = %q, want %q", msg.message, "third")
+		}
+	default:
+		t.Fatal("expected \"third\" to be queued in place of the evicted \"second\"")
+	}
+	select {
+	case msg := <-ob.ch:
+		t.Fatalf("unexpected extra queued message %q; only \"third\" should remain", msg.message)
+	default:
+	}
+}
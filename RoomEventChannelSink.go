@@ -0,0 +1,28 @@
+package socketio
+
+// ChannelRoomEventSink is a RoomEventSink that delivers every RoomEvent onto a channel. It is
+// meant for tests and small in-process consumers (e.g. asserting on join/leave order) rather
+// than production fan-out, where a full queue simply blocks the broadcaster.
+type ChannelRoomEventSink struct {
+	Events chan RoomEvent
+}
+
+// NewChannelRoomEventSink returns a ChannelRoomEventSink whose Events channel is buffered to
+// size.
+func NewChannelRoomEventSink(size int) *ChannelRoomEventSink {
+	return &ChannelRoomEventSink{
+		Events: make(chan RoomEvent, size),
+	}
+}
+
+// Update sends event on Events. It never returns an error, so the sink is never auto-dropped.
+func (s *ChannelRoomEventSink) Update(event RoomEvent) error {
+	s.Events <- event
+	return nil
+}
+
+// Close closes Events. Update must not be called after Close.
+func (s *ChannelRoomEventSink) Close() error {
+	close(s.Events)
+	return nil
+}
@@ -0,0 +1,548 @@
+// Package redis provides a socketio.BroadcastAdaptor backed by Redis pub/sub so that room
+// broadcasts reach sockets connected to any socketio.Server instance in a cluster, not just
+// the instance the socket happens to be attached to.
+//
+// This adaptor does not support socketio.RoomEventSink: Register is only available on the
+// concrete *socketio.Broadcast, and room membership changes made here are not published to or
+// observable from other instances in the cluster.
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/pschlump/socketio"
+)
+
+// Options configures the Redis-backed BroadcastAdaptor.
+type Options struct {
+	// Prefix is prepended to every pub/sub channel the adaptor uses. Defaults to "socket.io".
+	Prefix string
+
+	// Nsp identifies the namespace this adaptor serves. Defaults to "/".
+	Nsp string
+}
+
+// envelope is published on the room channel and replayed into local Emit calls by every
+// subscriber, including the one that published it.
+type envelope struct {
+	Nsp       string        `json:"nsp"`
+	Room      string        `json:"room"`
+	IgnoreSid string        `json:"ignoreSid,omitempty"`
+	Event     string        `json:"event"`
+	Args      []interface{} `json:"args,omitempty"`
+
+	// Uid identifies the publishing adaptor instance. It is only set by SendWithAck, whose
+	// local sockets are emitted to directly (see SendWithAck); loop uses it to avoid also
+	// delivering the published copy of the same envelope to those same local sockets.
+	Uid string `json:"uid,omitempty"`
+}
+
+// adaptor implements socketio.BroadcastAdaptor on top of Redis pub/sub. Room membership is
+// tracked locally per instance; Send fans a message out to every instance via Redis, and each
+// instance replays it to the sockets it holds locally.
+type adaptor struct {
+	nsp    string
+	prefix string
+	uid    string
+	client *goredis.Client
+	pubsub *goredis.PubSub
+
+	mu          sync.RWMutex
+	roomSet     map[string]map[string]socketio.Socket
+	socketRooms map[string]map[string]struct{}
+}
+
+// New returns a socketio.BroadcastAdaptor that fans room broadcasts out over client's Redis
+// pub/sub so every socketio.Server sharing the same client and namespace can deliver messages
+// to each other's connected sockets. Call socketio.SetAdaptor(redisadapter.New(...)) before
+// constructing the Server.
+func New(client *goredis.Client, opts Options) socketio.BroadcastAdaptor {
+	if opts.Prefix == "" {
+		opts.Prefix = "socket.io"
+	}
+	if opts.Nsp == "" {
+		opts.Nsp = "/"
+	}
+
+	a := &adaptor{
+		nsp:         opts.Nsp,
+		prefix:      opts.Prefix,
+		uid:         newUid(),
+		client:      client,
+		roomSet:     make(map[string]map[string]socketio.Socket),
+		socketRooms: make(map[string]map[string]struct{}),
+	}
+
+	a.pubsub = client.Subscribe(context.Background(), a.allChannel())
+	go a.loop()
+	return a
+}
+
+// newUid returns a random identifier for self-filtering in shouldDeliver. It must be unique
+// across the whole cluster, not just this process: a pointer address (e.g. fmt.Sprintf("%p", a))
+// is not good enough, since replicas of an identical binary routinely place early, similarly
+// sized allocations at the same address, which would make two different instances treat each
+// other's envelopes as their own and silently drop them.
+func newUid() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// channel returns the pub/sub channel name used to publish envelopes for room, e.g.
+// "socket.io#/#msg#lobby#". This instance subscribes to it literally (see subscribeRoom) only
+// while it holds at least one local member of room, rather than via a pattern subscription, so
+// that PUBSUB NUMSUB (used by NumberInRoom's clusterWide path) can actually count it: Redis
+// excludes pattern-subscribed clients from that count.
+func (a *adaptor) channel(room string) string {
+	return fmt.Sprintf("%s#%s#msg#%s#", a.prefix, a.nsp, room)
+}
+
+// allChannel returns the pub/sub channel name SendAll publishes a single envelope to, e.g.
+// "socket.io#/#all#". Routing SendAll through its own channel, rather than publishing once per
+// room, lets deliverAll dedupe against this instance's own local sockets before emitting, so a
+// socket joined to more than one room still only receives the message once.
+func (a *adaptor) allChannel() string {
+	return fmt.Sprintf("%s#%s#all#", a.prefix, a.nsp)
+}
+
+// subscribeRoom literally subscribes this instance to room's channel. Called, outside a.mu,
+// whenever room gains its first local member.
+func (a *adaptor) subscribeRoom(room string) error {
+	return a.pubsub.Subscribe(context.Background(), a.channel(room))
+}
+
+// unsubscribeRoom literally unsubscribes this instance from room's channel. Called, outside
+// a.mu, whenever room loses its last local member.
+func (a *adaptor) unsubscribeRoom(room string) error {
+	return a.pubsub.Unsubscribe(context.Background(), a.channel(room))
+}
+
+// loop translates incoming envelopes into local Emit calls until pubsub is closed. It reads off
+// the same a.pubsub that subscribeRoom/unsubscribeRoom and the Subscribe(allChannel) call in New
+// manage, so it sees every message published on a channel this instance is currently subscribed
+// to.
+func (a *adaptor) loop() {
+	for msg := range a.pubsub.Channel() {
+		if msg.Channel == a.allChannel() {
+			var env envelope
+			if json.Unmarshal([]byte(msg.Payload), &env) == nil {
+				a.deliverAll(env)
+			}
+			continue
+		}
+		if env, ok := a.shouldDeliver(msg.Channel, msg.Payload); ok {
+			a.deliver(env)
+		}
+	}
+}
+
+// shouldDeliver decodes payload and decides whether loop should hand it to deliver. Messages
+// are only ever published on the "msg#" segment this adaptor subscribes to, but channelName is
+// checked against the decoded envelope's own room as a defense-in-depth measure against ever
+// unmarshalling an unrelated payload as an envelope. It also filters out envelopes this same
+// adaptor instance published for SendWithAck, whose local sockets were already emitted to
+// directly (see SendWithAck).
+func (a *adaptor) shouldDeliver(channelName, payload string) (envelope, bool) {
+	var env envelope
+	if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		return envelope{}, false
+	}
+	if channelName != a.channel(env.Room) {
+		return envelope{}, false
+	}
+	if env.Uid != "" && env.Uid == a.uid {
+		return envelope{}, false
+	}
+	return env, true
+}
+
+// deliver emits env to every local socket in its room. Each Emit runs in its own goroutine
+// instead of on loop's goroutine: loop is the single reader draining pubsub for every room on
+// this instance, so a synchronous Emit to one backpressured local socket would otherwise stall
+// delivery of every subsequent pub/sub message, for every room, on the whole instance.
+func (a *adaptor) deliver(env envelope) {
+	a.mu.RLock()
+	sockets := a.roomSet[env.Room]
+	targets := make([]socketio.Socket, 0, len(sockets))
+	for id, s := range sockets {
+		if env.IgnoreSid != "" && id == env.IgnoreSid {
+			continue
+		}
+		targets = append(targets, s)
+	}
+	a.mu.RUnlock()
+
+	for _, s := range targets {
+		go s.Emit(env.Event, env.Args...)
+	}
+}
+
+// deliverAll emits env to every local socket known to this instance, across every room, each
+// exactly once, same as deliver but deduped across rooms instead of scoped to one.
+func (a *adaptor) deliverAll(env envelope) {
+	a.mu.RLock()
+	sent := make(map[string]struct{})
+	targets := make([]socketio.Socket, 0, len(a.socketRooms))
+	for _, sockets := range a.roomSet {
+		for id, s := range sockets {
+			if _, ok := sent[id]; ok {
+				continue
+			}
+			sent[id] = struct{}{}
+			targets = append(targets, s)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, s := range targets {
+		go s.Emit(env.Event, env.Args...)
+	}
+}
+
+// Get the joined rooms
+func (a *adaptor) Rooms(socket socketio.Socket) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	ret := []string{}
+	if socket == nil {
+		for room := range a.roomSet {
+			ret = append(ret, room)
+		}
+		return ret
+	}
+	for room := range a.socketRooms[socket.Id()] {
+		ret = append(ret, room)
+	}
+	return ret
+}
+
+// Whether room exists
+func (a *adaptor) Has(room string, socket socketio.Socket) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	sockets, ok := a.roomSet[room]
+	if !ok {
+		return false
+	}
+	if socket == nil {
+		return true
+	}
+	_, has := sockets[socket.Id()]
+	return has
+}
+
+// Get all the links in the specified room known to this instance
+func (a *adaptor) Clients(room string) map[string]socketio.Socket {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.roomSet[room]
+}
+
+// Join into a room
+func (a *adaptor) Join(room string, socket socketio.Socket) error {
+	a.mu.Lock()
+	sockets, ok := a.roomSet[room]
+	created := !ok
+	if !ok {
+		sockets = make(map[string]socketio.Socket)
+		a.roomSet[room] = sockets
+	}
+	sockets[socket.Id()] = socket
+
+	rooms, ok := a.socketRooms[socket.Id()]
+	if !ok {
+		rooms = make(map[string]struct{})
+		a.socketRooms[socket.Id()] = rooms
+	}
+	rooms[room] = struct{}{}
+	a.mu.Unlock()
+
+	if created {
+		return a.subscribeRoom(room)
+	}
+	return nil
+}
+
+// Disconnect from a room
+func (a *adaptor) Leave(room string, socket socketio.Socket) error {
+	a.mu.Lock()
+	emptied := a.leaveLocked(room, socket.Id())
+	a.mu.Unlock()
+
+	if emptied {
+		return a.unsubscribeRoom(room)
+	}
+	return nil
+}
+
+// leaveLocked removes sid from room, reporting whether room lost its last local member. Caller
+// must hold a.mu.
+func (a *adaptor) leaveLocked(room, sid string) (emptied bool) {
+	sockets, ok := a.roomSet[room]
+	if !ok {
+		return false
+	}
+	delete(sockets, sid)
+	if len(sockets) == 0 {
+		delete(a.roomSet, room)
+		emptied = true
+	}
+
+	if rooms, ok := a.socketRooms[sid]; ok {
+		delete(rooms, room)
+		if len(rooms) == 0 {
+			delete(a.socketRooms, sid)
+		}
+	}
+	return emptied
+}
+
+// LeaveAll removes socket from every room it has joined.
+func (a *adaptor) LeaveAll(socket socketio.Socket) error {
+	a.mu.Lock()
+	rooms := make([]string, 0, len(a.socketRooms[socket.Id()]))
+	for room := range a.socketRooms[socket.Id()] {
+		rooms = append(rooms, room)
+	}
+	emptied := make([]string, 0, len(rooms))
+	for _, room := range rooms {
+		if a.leaveLocked(room, socket.Id()) {
+			emptied = append(emptied, room)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, room := range emptied {
+		if err := a.unsubscribeRoom(room); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear removes every socket from room.
+func (a *adaptor) Clear(room string) error {
+	a.mu.Lock()
+	sockets := a.roomSet[room]
+	existed := len(sockets) > 0
+	for id := range sockets {
+		if rooms, ok := a.socketRooms[id]; ok {
+			delete(rooms, room)
+			if len(rooms) == 0 {
+				delete(a.socketRooms, id)
+			}
+		}
+	}
+	delete(a.roomSet, room)
+	a.mu.Unlock()
+
+	if existed {
+		return a.unsubscribeRoom(room)
+	}
+	return nil
+}
+
+// Send publishes message with args to room so every instance in the cluster, including this
+// one, can deliver it to the sockets it holds locally. If ignore is not nil, its socket id is
+// skipped everywhere.
+func (a *adaptor) Send(ignore socketio.Socket, room, message string, args ...interface{}) error {
+	env := envelope{
+		Nsp:   a.nsp,
+		Room:  room,
+		Event: message,
+		Args:  args,
+	}
+	if ignore != nil {
+		env.IgnoreSid = ignore.Id()
+	}
+
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return a.client.Publish(context.Background(), a.channel(room), string(payload)).Err()
+}
+
+// SendVolatile behaves like Send: pub/sub fan-out is already best-effort, so there is no local
+// queue to skip here.
+func (a *adaptor) SendVolatile(ignore socketio.Socket, room, message string, args ...interface{}) error {
+	return a.Send(ignore, room, message, args...)
+}
+
+// SendBinary behaves like Send, but reads io.Reader args into []byte first so their contents are
+// published rather than dropped. The envelope is JSON-encoded, so []byte args, including those
+// read from an io.Reader, reach Emit on every instance as base64-encoded strings, not as binary
+// attachments.
+func (a *adaptor) SendBinary(ignore socketio.Socket, room, message string, args ...interface{}) error {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if r, ok := arg.(io.Reader); ok {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			converted[i] = data
+			continue
+		}
+		converted[i] = arg
+	}
+	return a.Send(ignore, room, message, converted...)
+}
+
+// SendWithAck sends message with args to every socket in room. Sockets known to this instance
+// are emitted to directly and their replies are collected; the envelope is also published so
+// sockets attached to other instances in the cluster still receive message. Acknowledgements
+// never cross the pub/sub fan-out, though: only sockets on this instance can appear in the
+// returned results.
+func (a *adaptor) SendWithAck(ctx context.Context, ignore socketio.Socket, room, message string, args ...interface{}) (<-chan socketio.AckResult, error) {
+	env := envelope{
+		Nsp:   a.nsp,
+		Room:  room,
+		Event: message,
+		Args:  args,
+		Uid:   a.uid,
+	}
+	if ignore != nil {
+		env.IgnoreSid = ignore.Id()
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.client.Publish(context.Background(), a.channel(room), string(payload)).Err(); err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	sockets := a.roomSet[room]
+	targets := make([]socketio.Socket, 0, len(sockets))
+	for id, s := range sockets {
+		if ignore != nil && ignore.Id() == id {
+			continue
+		}
+		targets = append(targets, s)
+	}
+	a.mu.RUnlock()
+
+	results := make(chan socketio.AckResult, len(targets))
+	if len(targets) == 0 {
+		close(results)
+		return results, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for _, s := range targets {
+		sid := s.Id()
+		done := make(chan struct{})
+		var once sync.Once
+		// resolve sends res and closes done together, both guarded by the same once, so a
+		// duplicate or retried ack call for sid can never close an already-closed done.
+		resolve := func(res socketio.AckResult) {
+			once.Do(func() {
+				results <- res
+				close(done)
+			})
+		}
+
+		ackFn := func(data interface{}) {
+			resolve(socketio.AckResult{Sid: sid, Data: data})
+		}
+
+		if err := s.Emit(message, append(append([]interface{}{}, args...), ackFn)...); err != nil {
+			resolve(socketio.AckResult{Sid: sid, Err: err})
+		}
+
+		go func() {
+			defer wg.Done()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				resolve(socketio.AckResult{Sid: sid, Err: ctx.Err()})
+			}
+		}()
+	}
+
+	return results, nil
+}
+
+// SendAll sends message with args to every socket in every room, across every instance in the
+// cluster. A socket joined to more than one room only receives the message once: the envelope is
+// published once to allChannel rather than once per room, and each instance dedupes its own
+// local sockets across rooms in deliverAll before emitting.
+func (a *adaptor) SendAll(message string, args ...interface{}) error {
+	env := envelope{
+		Nsp:   a.nsp,
+		Event: message,
+		Args:  args,
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return a.client.Publish(context.Background(), a.allChannel(), string(payload)).Err()
+}
+
+// ForEach calls fn once for every socket currently in room on this instance. Membership is
+// snapshotted under a.mu and released before fn runs, so fn is free to call back into
+// Join/Leave/LeaveAll/Clear/Send* on the same adaptor without deadlocking.
+func (a *adaptor) ForEach(room string, fn func(socketio.Socket)) error {
+	a.mu.RLock()
+	sockets := a.roomSet[room]
+	targets := make([]socketio.Socket, 0, len(sockets))
+	for _, s := range sockets {
+		targets = append(targets, s)
+	}
+	a.mu.RUnlock()
+
+	for _, s := range targets {
+		fn(s)
+	}
+	return nil
+}
+
+// Len returns the number of sockets currently in room on this instance.
+func (a *adaptor) Len(room string) int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return len(a.roomSet[room])
+}
+
+// NumberInRoom returns the number of sockets in room known to this instance. Pass
+// clusterWide = true to instead ask Redis how many subscribers the room's channel has across
+// the whole cluster via PUBSUB NUMSUB.
+func (a *adaptor) NumberInRoom(room string, clusterWide bool) (int, error) {
+	if !clusterWide {
+		return a.Len(room), nil
+	}
+
+	counts, err := a.client.PubSubNumSub(context.Background(), a.channel(room)).Result()
+	if err != nil {
+		return 0, err
+	}
+	return int(counts[a.channel(room)]), nil
+}
+
+// Close stops the background subscriber goroutine.
+func (a *adaptor) Close() error {
+	return a.pubsub.Close()
+}
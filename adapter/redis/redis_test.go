@@ -0,0 +1,217 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/pschlump/socketio"
+)
+
+// newTestAdaptor builds an adaptor with only the fields its pure, non-network methods need. It
+// deliberately never calls New, so these tests never touch a real Redis connection.
+func newTestAdaptor(uid string) *adaptor {
+	return &adaptor{
+		nsp:    "/",
+		prefix: "socket.io",
+		uid:    uid,
+	}
+}
+
+// fakeSocket is a minimal socketio.Socket used to exercise deliverAll without a real connection.
+type fakeSocket struct {
+	id string
+
+	mu      sync.Mutex
+	emitted []string
+}
+
+func (s *fakeSocket) Id() string { return s.id }
+
+func (s *fakeSocket) Emit(name string, args ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emitted = append(s.emitted, name)
+	return nil
+}
+
+func (s *fakeSocket) Disconnect() error { return nil }
+
+func (s *fakeSocket) emittedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.emitted)
+}
+
+func TestChannelHasMsgPrefix(t *testing.T) {
+	a := newTestAdaptor("")
+
+	msgChan := a.channel("lobby")
+
+	pattern := "socket.io#/#msg#"
+	if len(msgChan) < len(pattern) || msgChan[:len(pattern)] != pattern {
+		t.Fatalf("channel() = %q, want prefix %q", msgChan, pattern)
+	}
+}
+
+func TestShouldDeliverAcceptsMatchingEnvelope(t *testing.T) {
+	a := newTestAdaptor("")
+
+	payload, err := json.Marshal(envelope{Nsp: "/", Room: "lobby", Event: "chat", Args: []interface{}{"hi"}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	env, ok := a.shouldDeliver(a.channel("lobby"), string(payload))
+	if !ok {
+		t.Fatal("shouldDeliver rejected a well-formed, matching envelope")
+	}
+	if env.Room != "lobby" || env.Event != "chat" {
+		t.Fatalf("env = %+v, want Room=lobby Event=chat", env)
+	}
+}
+
+func TestShouldDeliverRejectsMismatchedChannel(t *testing.T) {
+	a := newTestAdaptor("")
+
+	payload, err := json.Marshal(envelope{Nsp: "/", Room: "lobby", Event: "chat"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	// The envelope claims room "lobby" but arrived on a different room's channel.
+	if _, ok := a.shouldDeliver(a.channel("other-room"), string(payload)); ok {
+		t.Fatal("shouldDeliver accepted an envelope whose room didn't match the channel it arrived on")
+	}
+}
+
+func TestShouldDeliverSkipsOwnUid(t *testing.T) {
+	a := newTestAdaptor("adaptor-1")
+
+	payload, err := json.Marshal(envelope{Nsp: "/", Room: "lobby", Event: "chat", Uid: "adaptor-1"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	if _, ok := a.shouldDeliver(a.channel("lobby"), string(payload)); ok {
+		t.Fatal("shouldDeliver accepted an envelope carrying this adaptor's own uid")
+	}
+
+	// A different uid (or none, for a plain Send) must still be delivered.
+	payload, err = json.Marshal(envelope{Nsp: "/", Room: "lobby", Event: "chat", Uid: "adaptor-2"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if _, ok := a.shouldDeliver(a.channel("lobby"), string(payload)); !ok {
+		t.Fatal("shouldDeliver rejected an envelope carrying a different adaptor's uid")
+	}
+}
+
+func TestNewUidIsRandomAndUnique(t *testing.T) {
+	a := newUid()
+	b := newUid()
+
+	if a == "" || b == "" {
+		t.Fatal("newUid returned an empty id")
+	}
+	if a == b {
+		t.Fatalf("newUid returned the same id twice: %q", a)
+	}
+}
+
+func TestLeaveLockedReportsEmptied(t *testing.T) {
+	a := &adaptor{
+		roomSet:     make(map[string]map[string]socketio.Socket),
+		socketRooms: make(map[string]map[string]struct{}),
+	}
+	s1, s2 := &fakeSocket{id: "s1"}, &fakeSocket{id: "s2"}
+	a.roomSet["lobby"] = map[string]socketio.Socket{"s1": s1, "s2": s2}
+	a.socketRooms["s1"] = map[string]struct{}{"lobby": {}}
+	a.socketRooms["s2"] = map[string]struct{}{"lobby": {}}
+
+	if emptied := a.leaveLocked("lobby", "s1"); emptied {
+		t.Fatal("leaveLocked reported room emptied while s2 is still a member")
+	}
+	if emptied := a.leaveLocked("lobby", "s2"); !emptied {
+		t.Fatal("leaveLocked did not report room emptied after its last member left")
+	}
+	if _, ok := a.roomSet["lobby"]; ok {
+		t.Fatal("leaveLocked left an empty room behind in roomSet")
+	}
+}
+
+func TestAllChannelDiffersFromMsgChannel(t *testing.T) {
+	a := newTestAdaptor("")
+
+	all := a.allChannel()
+	if all == a.channel("lobby") {
+		t.Fatalf("allChannel() = %q, collides with a room channel", all)
+	}
+}
+
+func TestDeliverAllDedupesSocketAcrossRooms(t *testing.T) {
+	a := &adaptor{
+		nsp:         "/",
+		prefix:      "socket.io",
+		roomSet:     make(map[string]map[string]socketio.Socket),
+		socketRooms: make(map[string]map[string]struct{}),
+	}
+
+	shared := &fakeSocket{id: "shared"}
+	a.roomSet["room-a"] = map[string]socketio.Socket{"shared": shared}
+	a.roomSet["room-b"] = map[string]socketio.Socket{"shared": shared}
+
+	a.deliverAll(envelope{Nsp: "/", Event: "chat", Args: []interface{}{"hi"}})
+
+	deadline := time.Now().Add(time.Second)
+	for shared.emittedCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := shared.emittedCount(); n != 1 {
+		t.Fatalf("shared socket in two rooms was emitted to %d times, want 1", n)
+	}
+}
+
+func TestForEachReentrant(t *testing.T) {
+	a := &adaptor{
+		nsp:    "/",
+		prefix: "socket.io",
+		// Leave's Subscribe/Unsubscribe/Publish all go through a client that never connects;
+		// they fail fast (connection refused) rather than hanging, which is all this test
+		// needs from them.
+		client:      goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:1"}),
+		roomSet:     make(map[string]map[string]socketio.Socket),
+		socketRooms: make(map[string]map[string]struct{}),
+	}
+	a.pubsub = a.client.Subscribe(context.Background(), a.allChannel())
+	for i := 0; i < 3; i++ {
+		s := &fakeSocket{id: fmt.Sprintf("s%d", i)}
+		if a.roomSet["lobby"] == nil {
+			a.roomSet["lobby"] = make(map[string]socketio.Socket)
+		}
+		a.roomSet["lobby"][s.id] = s
+		a.socketRooms[s.id] = map[string]struct{}{"lobby": {}}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		a.ForEach("lobby", func(s socketio.Socket) {
+			a.Leave("lobby", s)
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ForEach deadlocked calling back into Leave")
+	}
+
+	if n := a.Len("lobby"); n != 0 {
+		t.Errorf("Len(\"lobby\") = %d, want 0", n)
+	}
+}